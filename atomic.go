@@ -0,0 +1,41 @@
+package kvstore
+
+// GetPair returns the value and version currently associated with
+// key, for use with AtomicPut/AtomicDelete.
+//
+// Returns ErrNotSupported if the driver doesn't implement AtomicConn.
+func (s *KVStore) GetPair(key string) (*KVPair, error) {
+	ac, ok := s.conn.(AtomicConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return ac.GetPair(key)
+}
+
+// AtomicPut sets key to value only if its current state matches
+// previous: pass nil to require that key doesn't exist yet, otherwise
+// previous should be the KVPair last read with GetPair. Returns the
+// KVPair written, or ErrCASMismatch if key changed since previous was
+// read.
+//
+// Returns ErrNotSupported if the driver doesn't implement AtomicConn.
+func (s *KVStore) AtomicPut(key string, value []byte, previous *KVPair) (*KVPair, error) {
+	ac, ok := s.conn.(AtomicConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return ac.AtomicPut(key, value, previous)
+}
+
+// AtomicDelete removes key only if its current version matches
+// previous.Version. Returns ErrCASMismatch if key was updated since
+// previous was read, or ErrNotFound if key doesn't exist.
+//
+// Returns ErrNotSupported if the driver doesn't implement AtomicConn.
+func (s *KVStore) AtomicDelete(key string, previous *KVPair) error {
+	ac, ok := s.conn.(AtomicConn)
+	if !ok {
+		return ErrNotSupported
+	}
+	return ac.AtomicDelete(key, previous)
+}
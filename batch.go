@@ -0,0 +1,50 @@
+package kvstore
+
+// Batch accumulates Set/Delete operations to be committed together.
+//
+// Create one with KVStore.Batch(), add operations, then call Commit().
+// A Batch is not safe to use from multiple goroutines.
+type Batch struct {
+	store *KVStore
+	ops   []Op
+}
+
+// Batch returns a new Batch tied to this KVStore.
+func (s *KVStore) Batch() *Batch {
+	return &Batch{store: s}
+}
+
+// Set adds a Set operation to the batch.
+func (b *Batch) Set(key string, value []byte) {
+	b.ops = append(b.ops, Op{Kind: OpSet, Key: key, Value: value})
+}
+
+// Delete adds a Delete operation to the batch.
+func (b *Batch) Delete(key string) {
+	b.ops = append(b.ops, Op{Kind: OpDelete, Key: key})
+}
+
+// Commit applies all accumulated operations.
+//
+// If the driver's Conn implements BatchConn, the operations are
+// committed atomically through it. Otherwise Commit falls back to
+// calling Set/Delete on the underlying Conn sequentially.
+func (b *Batch) Commit() error {
+	if bc, ok := b.store.conn.(BatchConn); ok {
+		return bc.Commit(b.ops)
+	}
+
+	for _, op := range b.ops {
+		switch op.Kind {
+		case OpSet:
+			if err := b.store.conn.Set(op.Key, op.Value); err != nil {
+				return err
+			}
+		case OpDelete:
+			if err := b.store.conn.Delete(op.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
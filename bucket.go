@@ -0,0 +1,228 @@
+package kvstore
+
+import (
+	"context"
+	"strings"
+)
+
+// bucketSep separates a bucket's prefix from the key inside it, so a
+// bucket named "a" can't collide with a key "a:b" in the root bucket.
+const bucketSep = "\x00"
+
+// Bucket returns a KVStore scoped to a logical namespace. Set, Get,
+// Delete and iteration performed through the returned KVStore only see
+// keys within this bucket: identical keys in different buckets don't
+// collide, and deleting a key in one bucket doesn't affect another.
+//
+// If the driver implements NamespacedDriver, its native namespace
+// support is used. Otherwise Bucket falls back to a generic,
+// key-prefixing Conn implemented by this package.
+func (s *KVStore) Bucket(name string) *KVStore {
+	if nd, ok := s.conn.(NamespacedDriver); ok {
+		if conn, err := nd.Namespace(name); err == nil {
+			return &KVStore{conn: conn}
+		}
+	}
+	return &KVStore{conn: &bucketConn{conn: s.conn, prefix: name + bucketSep}}
+}
+
+// bucketConn is the generic, prefix-based fallback used by Bucket when
+// the driver has no native namespace support.
+type bucketConn struct {
+	conn   Conn
+	prefix string
+}
+
+func (b *bucketConn) Set(key string, value []byte) error {
+	return b.conn.Set(b.prefix+key, value)
+}
+
+func (b *bucketConn) Get(key string) ([]byte, error) {
+	return b.conn.Get(b.prefix + key)
+}
+
+func (b *bucketConn) Delete(key string) error {
+	return b.conn.Delete(b.prefix + key)
+}
+
+func (b *bucketConn) Close() error {
+	return b.conn.Close()
+}
+
+func (b *bucketConn) SetWith(key string, value []byte, opts Options) error {
+	return b.conn.SetWith(b.prefix+key, value, opts)
+}
+
+func (b *bucketConn) GetWith(key string, opts Options) ([]byte, error) {
+	return b.conn.GetWith(b.prefix+key, opts)
+}
+
+func (b *bucketConn) DeleteWith(key string, opts Options) error {
+	return b.conn.DeleteWith(b.prefix+key, opts)
+}
+
+// Namespace implements NamespacedDriver, so nesting a bucket on top of
+// the generic fallback just extends the prefix.
+func (b *bucketConn) Namespace(name string) (Conn, error) {
+	return &bucketConn{conn: b.conn, prefix: b.prefix + name + bucketSep}, nil
+}
+
+// Iterator forwards to the wrapped Conn's IterableConn, if it
+// implements one, scoping the range to this bucket and stripping the
+// prefix back off the keys it yields. Implements kvstore.IterableConn.
+func (b *bucketConn) Iterator(start, end string) (Iterator, error) {
+	ic, ok := b.conn.(IterableConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	bucketEnd := prefixRangeEnd(b.prefix)
+	if end != "" {
+		bucketEnd = b.prefix + end
+	}
+
+	inner, err := ic.Iterator(b.prefix+start, bucketEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &bucketIterator{inner: inner, prefix: b.prefix}, nil
+}
+
+// bucketIterator strips a bucket's key prefix off an underlying
+// Iterator's results.
+type bucketIterator struct {
+	inner  Iterator
+	prefix string
+}
+
+func (it *bucketIterator) Next() bool { return it.inner.Next() }
+func (it *bucketIterator) Key() string {
+	return strings.TrimPrefix(it.inner.Key(), it.prefix)
+}
+func (it *bucketIterator) Value() []byte { return it.inner.Value() }
+func (it *bucketIterator) Error() error  { return it.inner.Error() }
+func (it *bucketIterator) Close() error  { return it.inner.Close() }
+
+// GetPair forwards to the wrapped Conn's AtomicConn, if it implements
+// one. Implements kvstore.AtomicConn.
+func (b *bucketConn) GetPair(key string) (*KVPair, error) {
+	ac, ok := b.conn.(AtomicConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	pair, err := ac.GetPair(b.prefix + key)
+	if err != nil {
+		return nil, err
+	}
+	return &KVPair{Key: key, Value: pair.Value, Version: pair.Version}, nil
+}
+
+// AtomicPut forwards to the wrapped Conn's AtomicConn, if it
+// implements one. Implements kvstore.AtomicConn.
+func (b *bucketConn) AtomicPut(key string, value []byte, previous *KVPair) (*KVPair, error) {
+	ac, ok := b.conn.(AtomicConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	pair, err := ac.AtomicPut(b.prefix+key, value, b.scopedPair(previous))
+	if err != nil {
+		return nil, err
+	}
+	return &KVPair{Key: key, Value: pair.Value, Version: pair.Version}, nil
+}
+
+// AtomicDelete forwards to the wrapped Conn's AtomicConn, if it
+// implements one. Implements kvstore.AtomicConn.
+func (b *bucketConn) AtomicDelete(key string, previous *KVPair) error {
+	ac, ok := b.conn.(AtomicConn)
+	if !ok {
+		return ErrNotSupported
+	}
+	return ac.AtomicDelete(b.prefix+key, b.scopedPair(previous))
+}
+
+// scopedPair returns a copy of pair with its Key prefixed for the
+// wrapped Conn, or nil if pair is nil.
+func (b *bucketConn) scopedPair(pair *KVPair) *KVPair {
+	if pair == nil {
+		return nil
+	}
+	scoped := *pair
+	scoped.Key = b.prefix + pair.Key
+	return &scoped
+}
+
+// Commit forwards to the wrapped Conn's BatchConn, if it implements
+// one, prefixing each op's key. Otherwise it applies the ops with
+// sequential Set/Delete calls on the wrapped Conn. Implements
+// kvstore.BatchConn.
+func (b *bucketConn) Commit(ops []Op) error {
+	bc, ok := b.conn.(BatchConn)
+	if !ok {
+		for _, op := range ops {
+			var err error
+			switch op.Kind {
+			case OpSet:
+				err = b.Set(op.Key, op.Value)
+			case OpDelete:
+				err = b.Delete(op.Key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scoped := make([]Op, len(ops))
+	for i, op := range ops {
+		scoped[i] = op
+		scoped[i].Key = b.prefix + op.Key
+	}
+	return bc.Commit(scoped)
+}
+
+// Watch forwards to the wrapped Conn's WatcherConn, if it implements
+// one, stripping the prefix back off event keys. Implements
+// kvstore.WatcherConn.
+func (b *bucketConn) Watch(ctx context.Context, key string, opts WatchOptions) (<-chan Event, error) {
+	wc, ok := b.conn.(WatcherConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	inner, err := wc.Watch(ctx, b.prefix+key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.unscopeEvents(inner), nil
+}
+
+// WatchPrefix forwards to the wrapped Conn's WatcherConn, if it
+// implements one, stripping the prefix back off event keys.
+// Implements kvstore.WatcherConn.
+func (b *bucketConn) WatchPrefix(ctx context.Context, prefix string, opts WatchOptions) (<-chan Event, error) {
+	wc, ok := b.conn.(WatcherConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	inner, err := wc.WatchPrefix(ctx, b.prefix+prefix, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.unscopeEvents(inner), nil
+}
+
+// unscopeEvents strips this bucket's prefix off every event's Key as
+// it's forwarded from inner to the returned channel, closing the
+// returned channel once inner is closed.
+func (b *bucketConn) unscopeEvents(inner <-chan Event) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		for ev := range inner {
+			ev.Key = strings.TrimPrefix(ev.Key, b.prefix)
+			out <- ev
+		}
+	}()
+	return out
+}
@@ -1,5 +1,7 @@
 package kvstore
 
+import "context"
+
 // Driver interface used by KVStore
 type Driver interface {
 	// Open is called by New. It should return a Conn ready to be used.
@@ -22,6 +24,148 @@ type Conn interface {
 	// Close will close the connection. This connection should not
 	// be used anymore.
 	Close() error
+
+	// SetWith sets key to value honoring opts. Should return
+	// ErrOptionNotSupported if an option set in opts can't be honored,
+	// rather than silently ignoring it.
+	SetWith(key string, value []byte, opts Options) error
+
+	// GetWith returns the value associated with key honoring opts.
+	// Should return ErrNotFound if key doesn't exist, or
+	// ErrOptionNotSupported if an option set in opts can't be honored.
+	GetWith(key string, opts Options) (value []byte, err error)
+
+	// DeleteWith removes key honoring opts. Should return
+	// ErrOptionNotSupported if an option set in opts can't be honored.
+	DeleteWith(key string, opts Options) error
+}
+
+// OpKind identifies the kind of operation in a batch.
+type OpKind int
+
+// Kinds of operations supported by a batch
+const (
+	OpSet OpKind = iota
+	OpDelete
+)
+
+// Op is a single operation accumulated by a Batch and passed to
+// BatchConn.Commit
+type Op struct {
+	Kind  OpKind
+	Key   string
+	Value []byte
+}
+
+// BatchConn is an optional interface a Conn may implement to commit
+// multiple operations atomically.
+//
+// Drivers that can't do better than sequential Set/Delete calls don't
+// need to implement it: KVStore.Batch() falls back to calling Set/Delete
+// on Conn directly when BatchConn isn't implemented.
+type BatchConn interface {
+	// Commit applies ops atomically, in order.
+	Commit(ops []Op) error
+}
+
+// IterableConn is an optional interface a Conn may implement to
+// support range iteration over its keys.
+type IterableConn interface {
+	// Iterator returns an Iterator over the half-open range
+	// [start, end). An empty end means no upper bound.
+	Iterator(start, end string) (Iterator, error)
+}
+
+// NamespacedDriver is an optional interface a Conn may implement when
+// the underlying backend has native support for scoping a connection
+// to a namespace (e.g. a Mongo collection, a Redis key prefix backed
+// by SCAN MATCH, or a MySQL table per bucket).
+//
+// Drivers without native support don't need to implement it: Bucket()
+// falls back to a generic key-prefixing Conn implemented by this
+// package.
+type NamespacedDriver interface {
+	// Namespace returns a Conn scoped to name.
+	Namespace(name string) (Conn, error)
+}
+
+// KVPair is a key/value pair with the version it had when read,
+// returned by GetPair and accepted by AtomicPut/AtomicDelete as the
+// expected previous state.
+type KVPair struct {
+	Key     string
+	Value   []byte
+	Version uint64
+}
+
+// AtomicConn is an optional interface a Conn may implement to support
+// compare-and-swap style coordination.
+//
+// Drivers without a native way to do this atomically (e.g. Redis via
+// WATCH/MULTI, MySQL via row-versioning) shouldn't implement it:
+// KVStore.AtomicPut/AtomicDelete return ErrNotSupported rather than
+// fall back to a racy read-then-write.
+type AtomicConn interface {
+	// GetPair returns the value and version currently associated with
+	// key. Should return ErrNotFound if key doesn't exist.
+	GetPair(key string) (*KVPair, error)
+
+	// AtomicPut sets key to value only if its current state matches
+	// previous: nil means the key must not exist, otherwise its
+	// version must match previous.Version. Returns the KVPair written,
+	// or ErrCASMismatch if the check failed.
+	AtomicPut(key string, value []byte, previous *KVPair) (*KVPair, error)
+
+	// AtomicDelete removes key only if its current version matches
+	// previous.Version. Returns ErrCASMismatch if the check failed, or
+	// ErrNotFound if key doesn't exist.
+	AtomicDelete(key string, previous *KVPair) error
+}
+
+// EventType identifies the kind of change a watch Event reports.
+type EventType int
+
+// Kinds of change reported by a watch Event
+const (
+	EventPut EventType = iota
+	EventDelete
+)
+
+// Event reports a single Put or Delete observed by a watch.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value []byte
+}
+
+// WatchOptions configures a watch subscription.
+type WatchOptions struct {
+	// ChannelSize sets the buffer size of the returned event channel.
+	// Unbuffered (0) by default.
+	ChannelSize int
+}
+
+// WatchOption sets a field on WatchOptions.
+type WatchOption func(*WatchOptions)
+
+// WithChannelSize sets the buffer size of the channel returned by
+// Watch/WatchPrefix. Events are dropped, not blocked on, once the
+// buffer is full.
+func WithChannelSize(n int) WatchOption {
+	return func(o *WatchOptions) { o.ChannelSize = n }
+}
+
+// WatcherConn is an optional interface a Conn may implement to notify
+// subscribers of Put/Delete events on keys or prefixes (natively
+// supported by backends like etcd or Redis keyspace notifications).
+type WatcherConn interface {
+	// Watch returns a channel of Events for key. The channel is
+	// closed when ctx is done.
+	Watch(ctx context.Context, key string, opts WatchOptions) (<-chan Event, error)
+
+	// WatchPrefix returns a channel of Events for all keys starting
+	// with prefix. The channel is closed when ctx is done.
+	WatchPrefix(ctx context.Context, prefix string, opts WatchOptions) (<-chan Event, error)
 }
 
 var drivers = make(map[string]Driver)
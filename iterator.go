@@ -0,0 +1,85 @@
+package kvstore
+
+import "errors"
+
+// ErrNotSupported is returned when a driver doesn't implement the
+// capability required to perform an operation.
+var ErrNotSupported = errors.New("kvstore: not supported by driver")
+
+// Iterator iterates over keys in a range, in ascending order.
+//
+// Typical usage:
+//
+//	it, err := store.Iterator("a", "z")
+//	if err != nil {
+//		// handle err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+//	if err := it.Error(); err != nil {
+//		// handle err
+//	}
+type Iterator interface {
+	// Next advances the iterator, returning false when there are no
+	// more keys or an error occurred. Check Error() after Next()
+	// returns false.
+	Next() bool
+
+	// Key returns the key at the current position. Only valid after
+	// a call to Next() that returned true.
+	Key() string
+
+	// Value returns the value at the current position. Only valid
+	// after a call to Next() that returned true.
+	Value() []byte
+
+	// Error returns the first error encountered while iterating, if
+	// any.
+	Error() error
+
+	// Close releases resources associated with the iterator. It
+	// should be called once the iterator is no longer needed.
+	Close() error
+}
+
+// Iterator returns an Iterator over all keys in the half-open range
+// [start, end). Keys are visited in ascending order.
+//
+// Returns ErrNotSupported if the driver doesn't implement
+// IterableConn.
+func (s *KVStore) Iterator(start, end string) (Iterator, error) {
+	ic, ok := s.conn.(IterableConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return ic.Iterator(start, end)
+}
+
+// PrefixIterator returns an Iterator over all keys starting with
+// prefix, in ascending order.
+//
+// Returns ErrNotSupported if the driver doesn't implement
+// IterableConn.
+func (s *KVStore) PrefixIterator(prefix string) (Iterator, error) {
+	return s.Iterator(prefix, prefixRangeEnd(prefix))
+}
+
+// prefixRangeEnd returns the smallest string greater than every string
+// with the given prefix, so that [prefix, prefixRangeEnd(prefix)) is
+// exactly the half-open range of keys starting with prefix.
+//
+// An empty prefix has no upper bound, so an empty string is returned,
+// meaning "no end".
+func prefixRangeEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return string(b[:i+1])
+		}
+	}
+	// prefix is empty or all 0xff bytes: no upper bound
+	return ""
+}
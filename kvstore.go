@@ -16,6 +16,18 @@ import (
 // Error returned when a key doesn't exist
 var ErrNotFound = errors.New("kvstore: key not found")
 
+// Error returned by AtomicPut/AtomicDelete when the key's current
+// version doesn't match the version passed in previous
+var ErrCASMismatch = errors.New("kvstore: compare-and-swap version mismatch")
+
+// Error returned by Set when WithIfNotExists is used and key already
+// exists
+var ErrKeyExists = errors.New("kvstore: key already exists")
+
+// Error returned by Set/Get/Delete when the driver can't honor an
+// option that was passed in, rather than silently ignoring it
+var ErrOptionNotSupported = errors.New("kvstore: option not supported by driver")
+
 // KVStore offers an API to save arbitrary values associated with
 // keys
 //
@@ -30,6 +42,9 @@ type KVStore struct {
 // The driver will be setup (call Open) passing driverInfo. The semantics
 // of driverInfo is driver dependent.
 //
+// The returned KVStore is the root bucket. Use Bucket to scope
+// operations to a namespace.
+//
 // Returns error if driver is not registered or if driver.Open fails.
 func New(driverName, driverInfo string) (*KVStore, error) {
 	d, ok := drivers[driverName]
@@ -51,20 +66,42 @@ func New(driverName, driverInfo string) (*KVStore, error) {
 //
 // The max key and value size are driver dependent. But kvstore requires that
 // all drivers support at least: 256 bytes for key and 1Mb for values
-func (s *KVStore) Set(key string, value []byte) (err error) {
-	return s.conn.Set(key, value)
+//
+// Accepts SetOptions such as WithTTL and WithIfNotExists. Returns
+// ErrOptionNotSupported if the driver can't honor one of them.
+func (s *KVStore) Set(key string, value []byte, opts ...SetOption) (err error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return s.conn.SetWith(key, value, o)
 }
 
 // Get will return the value associated with key.
 //
 // Will return ErrNotFound if key doesn't exist.
-func (s *KVStore) Get(key string) (value []byte, err error) {
-	return s.conn.Get(key)
+//
+// Accepts GetOptions such as WithConsistent and
+// WithGetDriverOption. Returns ErrOptionNotSupported if the driver
+// can't honor one of them.
+func (s *KVStore) Get(key string, opts ...GetOption) (value []byte, err error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return s.conn.GetWith(key, o)
 }
 
 // Delete will delete the key. If key is not found it's a no-op.
-func (s *KVStore) Delete(key string) error {
-	return s.conn.Delete(key)
+//
+// Accepts DeleteOptions such as WithDeleteDriverOption. Returns
+// ErrOptionNotSupported if the driver can't honor one of them.
+func (s *KVStore) Delete(key string, opts ...DeleteOption) error {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return s.conn.DeleteWith(key, o)
 }
 
 // Close will close the driver connection. Most drivers require this
@@ -123,6 +123,46 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// Get passes WithGetDriverOption through to Conn.GetWith()
+func TestGetDriverOption(t *testing.T) {
+	defer teardown()
+
+	conn := &MockConn{}
+	Register("mock", &MockDriver{OpenConn: conn})
+
+	store, err := New("mock", "")
+	if err != nil {
+		t.Fatal("new failed", err)
+	}
+
+	if _, err := store.Get("foo", WithGetDriverOption("region", "us-east")); err != nil {
+		t.Fatal(err)
+	}
+	if conn.GetOpts.DriverOptions["region"] != "us-east" {
+		t.Error("didn't pass driver option through to Conn.GetWith()")
+	}
+}
+
+// Delete passes WithDeleteDriverOption through to Conn.DeleteWith()
+func TestDeleteDriverOption(t *testing.T) {
+	defer teardown()
+
+	conn := &MockConn{}
+	Register("mock", &MockDriver{OpenConn: conn})
+
+	store, err := New("mock", "")
+	if err != nil {
+		t.Fatal("new failed", err)
+	}
+
+	if err := store.Delete("foo", WithDeleteDriverOption("region", "us-east")); err != nil {
+		t.Fatal(err)
+	}
+	if conn.DeleteOpts.DriverOptions["region"] != "us-east" {
+		t.Error("didn't pass driver option through to Conn.DeleteWith()")
+	}
+}
+
 // Register() panics if called twice with same name
 func TestRegisterDuplicate(t *testing.T) {
 	defer teardown()
@@ -186,6 +226,11 @@ type MockConn struct {
 
 	// mocks to Close method
 	CloseErr error
+
+	// set by SetWith/GetWith/DeleteWith
+	SetOpts    Options
+	GetOpts    Options
+	DeleteOpts Options
 }
 
 func (d *MockDriver) Open(info string) (Conn, error) {
@@ -213,6 +258,21 @@ func (c *MockConn) Close() error {
 	return c.CloseErr
 }
 
+func (c *MockConn) SetWith(key string, value []byte, opts Options) error {
+	c.SetOpts = opts
+	return c.Set(key, value)
+}
+
+func (c *MockConn) GetWith(key string, opts Options) ([]byte, error) {
+	c.GetOpts = opts
+	return c.Get(key)
+}
+
+func (c *MockConn) DeleteWith(key string, opts Options) error {
+	c.DeleteOpts = opts
+	return c.Delete(key)
+}
+
 func teardown() {
 	delete(drivers, "mock")
 }
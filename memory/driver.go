@@ -2,7 +2,11 @@
 package memory
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/igorsobreira/kvstore"
 )
@@ -19,52 +23,356 @@ type Driver struct{}
 //
 // It's safe to be used by multiples goroutines.
 type Conn struct {
-	data map[string][]byte
-	mux  sync.RWMutex
+	data      map[string][]byte
+	versions  map[string]uint64
+	expiresAt map[string]time.Time
+	subs      []*subscription
+	mux       sync.RWMutex
+}
+
+// subscription is a single Watch/WatchPrefix subscriber.
+type subscription struct {
+	key    string
+	prefix bool
+	ch     chan kvstore.Event
 }
 
 // Open returns a new Conn.
 //
 // Doesn't require any info, it's ignored
 func (d *Driver) Open(info string) (kvstore.Conn, error) {
-	return &Conn{data: make(map[string][]byte)}, nil
+	return &Conn{
+		data:      make(map[string][]byte),
+		versions:  make(map[string]uint64),
+		expiresAt: make(map[string]time.Time),
+	}, nil
 }
 
 // Set sets the value associated with the key. Override existing
-// value.
+// value. Equivalent to SetWith with the zero Options.
 func (c *Conn) Set(key string, value []byte) error {
+	return c.SetWith(key, value, kvstore.Options{})
+}
+
+// SetWith sets key to value honoring opts. Implements kvstore.Conn.
+//
+// Supports WithTTL and WithIfNotExists. Returns ErrOptionNotSupported
+// if opts.DriverOptions is set, since this driver has no
+// driver-specific options.
+func (c *Conn) SetWith(key string, value []byte, opts kvstore.Options) error {
+	if len(opts.DriverOptions) > 0 {
+		return kvstore.ErrOptionNotSupported
+	}
+
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
-	c.data[key] = value
+	if opts.IfNotExists && c.existsLocked(key) {
+		return kvstore.ErrKeyExists
+	}
+
+	c.set(key, value)
+
+	if opts.TTL > 0 {
+		c.expiresAt[key] = time.Now().Add(opts.TTL)
+	}
 	return nil
 }
 
+// set sets key to value, bumps its version and clears any TTL.
+// Caller must hold mux.
+func (c *Conn) set(key string, value []byte) {
+	c.data[key] = value
+	c.versions[key]++
+	delete(c.expiresAt, key)
+	c.notify(kvstore.Event{Type: kvstore.EventPut, Key: key, Value: value})
+}
+
+// existsLocked reports whether key currently has a live, unexpired
+// value. Caller must hold mux.
+func (c *Conn) existsLocked(key string) bool {
+	if _, ok := c.data[key]; !ok {
+		return false
+	}
+	return !c.expiredLocked(key)
+}
+
+// expiredLocked reports whether key has a TTL that has elapsed.
+// Caller must hold mux.
+func (c *Conn) expiredLocked(key string) bool {
+	t, ok := c.expiresAt[key]
+	return ok && time.Now().After(t)
+}
+
 // Get returns the value associated with key.
-// Returns ErrNotFound if key doesn't exist
+// Returns ErrNotFound if key doesn't exist. Equivalent to GetWith with
+// the zero Options.
 func (c *Conn) Get(key string) (value []byte, err error) {
-	c.mux.RLock()
-	defer c.mux.RUnlock()
+	return c.GetWith(key, kvstore.Options{})
+}
 
-	var ok bool
-	value, ok = c.data[key]
+// GetWith returns the value associated with key honoring opts.
+// Implements kvstore.Conn.
+//
+// Consistent is a no-op: a single in-process map is always
+// consistent. Returns ErrOptionNotSupported if opts.DriverOptions is
+// set.
+func (c *Conn) GetWith(key string, opts kvstore.Options) (value []byte, err error) {
+	if len(opts.DriverOptions) > 0 {
+		return nil, kvstore.ErrOptionNotSupported
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.expiredLocked(key) {
+		c.delete(key)
+		return nil, kvstore.ErrNotFound
+	}
 
+	value, ok := c.data[key]
 	if !ok {
-		return value, kvstore.ErrNotFound
+		return nil, kvstore.ErrNotFound
 	}
 	return value, nil
 }
 
-// Delete will remove key. Do nothing if key not found.
+// Delete will remove key. Do nothing if key not found. Equivalent to
+// DeleteWith with the zero Options.
 func (c *Conn) Delete(key string) error {
+	return c.DeleteWith(key, kvstore.Options{})
+}
+
+// DeleteWith removes key honoring opts. Implements kvstore.Conn.
+//
+// Returns ErrOptionNotSupported if opts.DriverOptions is set, since
+// this driver has no driver-specific options.
+func (c *Conn) DeleteWith(key string, opts kvstore.Options) error {
+	if len(opts.DriverOptions) > 0 {
+		return kvstore.ErrOptionNotSupported
+	}
+
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
-	delete(c.data, key)
+	c.delete(key)
 	return nil
 }
 
+// delete removes key, its version and its TTL. Watchers are only
+// notified if key existed. Caller must hold mux.
+func (c *Conn) delete(key string) {
+	_, existed := c.data[key]
+	delete(c.data, key)
+	delete(c.versions, key)
+	delete(c.expiresAt, key)
+	if existed {
+		c.notify(kvstore.Event{Type: kvstore.EventDelete, Key: key})
+	}
+}
+
 // Close is a no-op. Just to implement kvstore.Conn interface.
 func (c *Conn) Close() error {
 	return nil
 }
+
+// Iterator returns an Iterator over the half-open range [start, end).
+// It snapshots and sorts the matching keys under the RWMutex, so the
+// iterator's ordering is stable even if the Conn is mutated
+// afterwards. Implements kvstore.IterableConn.
+func (c *Conn) Iterator(start, end string) (kvstore.Iterator, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		if k < start {
+			continue
+		}
+		if end != "" && k >= end {
+			continue
+		}
+		if c.expiredLocked(k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = c.data[k]
+	}
+
+	return &iterator{keys: keys, values: values, pos: -1}, nil
+}
+
+// iterator is a snapshot-based kvstore.Iterator implementation.
+type iterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (it *iterator) Next() bool {
+	it.pos++
+	return it.pos < len(it.keys)
+}
+
+func (it *iterator) Key() string {
+	return it.keys[it.pos]
+}
+
+func (it *iterator) Value() []byte {
+	return it.values[it.pos]
+}
+
+func (it *iterator) Error() error {
+	return nil
+}
+
+func (it *iterator) Close() error {
+	return nil
+}
+
+// Commit applies ops atomically under the mutex. Implements
+// kvstore.BatchConn.
+func (c *Conn) Commit(ops []kvstore.Op) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, op := range ops {
+		switch op.Kind {
+		case kvstore.OpSet:
+			c.set(op.Key, op.Value)
+		case kvstore.OpDelete:
+			c.delete(op.Key)
+		}
+	}
+	return nil
+}
+
+// Watch returns a channel of Events for key. Implements
+// kvstore.WatcherConn.
+func (c *Conn) Watch(ctx context.Context, key string, opts kvstore.WatchOptions) (<-chan kvstore.Event, error) {
+	return c.subscribe(ctx, key, false, opts), nil
+}
+
+// WatchPrefix returns a channel of Events for keys starting with
+// prefix. Implements kvstore.WatcherConn.
+func (c *Conn) WatchPrefix(ctx context.Context, prefix string, opts kvstore.WatchOptions) (<-chan kvstore.Event, error) {
+	return c.subscribe(ctx, prefix, true, opts), nil
+}
+
+// subscribe registers a subscription and returns its event channel.
+// The subscription is removed and the channel closed when ctx is
+// done.
+func (c *Conn) subscribe(ctx context.Context, key string, prefix bool, opts kvstore.WatchOptions) <-chan kvstore.Event {
+	sub := &subscription{key: key, prefix: prefix, ch: make(chan kvstore.Event, opts.ChannelSize)}
+
+	c.mux.Lock()
+	c.subs = append(c.subs, sub)
+	c.mux.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.unsubscribe(sub)
+		close(sub.ch)
+	}()
+
+	return sub.ch
+}
+
+// unsubscribe removes sub from the subscriber list.
+func (c *Conn) unsubscribe(sub *subscription) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for i, s := range c.subs {
+		if s == sub {
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify delivers ev to every subscriber matching its key, dropping
+// the event if the subscriber's channel is full. Caller must hold mux.
+func (c *Conn) notify(ev kvstore.Event) {
+	for _, sub := range c.subs {
+		if sub.prefix {
+			if !strings.HasPrefix(ev.Key, sub.key) {
+				continue
+			}
+		} else if ev.Key != sub.key {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+}
+
+// GetPair returns the value and version associated with key.
+// Implements kvstore.AtomicConn.
+func (c *Conn) GetPair(key string) (*kvstore.KVPair, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.expiredLocked(key) {
+		c.delete(key)
+		return nil, kvstore.ErrNotFound
+	}
+
+	value, ok := c.data[key]
+	if !ok {
+		return nil, kvstore.ErrNotFound
+	}
+	return &kvstore.KVPair{Key: key, Value: value, Version: c.versions[key]}, nil
+}
+
+// AtomicPut sets key to value if its current state matches previous.
+// Implements kvstore.AtomicConn.
+func (c *Conn) AtomicPut(key string, value []byte, previous *kvstore.KVPair) (*kvstore.KVPair, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.expiredLocked(key) {
+		c.delete(key)
+	}
+	exists := c.existsLocked(key)
+
+	switch {
+	case previous == nil && exists:
+		return nil, kvstore.ErrCASMismatch
+	case previous != nil && !exists:
+		return nil, kvstore.ErrCASMismatch
+	case previous != nil && c.versions[key] != previous.Version:
+		return nil, kvstore.ErrCASMismatch
+	}
+
+	c.set(key, value)
+	return &kvstore.KVPair{Key: key, Value: value, Version: c.versions[key]}, nil
+}
+
+// AtomicDelete removes key if its current version matches
+// previous.Version. Implements kvstore.AtomicConn.
+func (c *Conn) AtomicDelete(key string, previous *kvstore.KVPair) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.expiredLocked(key) {
+		c.delete(key)
+	}
+	if !c.existsLocked(key) {
+		return kvstore.ErrNotFound
+	}
+	if previous == nil || c.versions[key] != previous.Version {
+		return kvstore.ErrCASMismatch
+	}
+
+	c.delete(key)
+	return nil
+}
@@ -1,7 +1,9 @@
 package memory
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/igorsobreira/kvstore"
 )
@@ -43,6 +45,212 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestBatch(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("keep", []byte("me"))
+
+	b := store.Batch()
+	b.Set("lang", []byte("go"))
+	b.Delete("keep")
+
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := store.Get("lang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byteSliceEqual([]byte("go"), val) {
+		t.Fatalf("invalid val: %#v", val)
+	}
+
+	_, err = store.Get("keep")
+	if err != kvstore.ErrNotFound {
+		t.Fatal("invalid err", err)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("b", []byte("2"))
+	store.Set("a", []byte("1"))
+	store.Set("c", []byte("3"))
+
+	it, err := store.Iterator("a", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("got %v, want [a b]", keys)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := store.Bucket("a")
+	b := store.Bucket("b")
+
+	a.Set("key", []byte("a-value"))
+	b.Set("key", []byte("b-value"))
+
+	val, err := a.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byteSliceEqual([]byte("a-value"), val) {
+		t.Fatalf("invalid val: %#v", val)
+	}
+
+	a.Delete("key")
+
+	val, err = b.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byteSliceEqual([]byte("b-value"), val) {
+		t.Fatalf("bucket b affected by bucket a delete: %#v", val)
+	}
+}
+
+func TestAtomicPut(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := store.AtomicPut("key", []byte("v1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.AtomicPut("key", []byte("v2"), nil); err != kvstore.ErrCASMismatch {
+		t.Fatal("expected ErrCASMismatch, got:", err)
+	}
+
+	pair, err = store.AtomicPut("key", []byte("v2"), pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AtomicDelete("key", pair); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("key"); err != kvstore.ErrNotFound {
+		t.Fatal("invalid err", err)
+	}
+}
+
+func TestAtomicPutExpiredKey(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set("lease", []byte("holder1"), kvstore.WithTTL(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := store.GetPair("lease"); err != kvstore.ErrNotFound {
+		t.Fatal("expected expired lease to read as not found, got:", err)
+	}
+
+	if _, err := store.AtomicPut("lease", []byte("holder2"), nil); err != nil {
+		t.Fatal("expected re-acquire of expired lease to succeed, got:", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := store.Watch(ctx, "lang", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Set("lang", []byte("go"))
+
+	select {
+	case ev := <-events:
+		if ev.Type != kvstore.EventPut || ev.Key != "lang" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for channel to close")
+	}
+}
+
+func TestSetTTL(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set("lang", []byte("go"), kvstore.WithTTL(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := store.Get("lang"); err != kvstore.ErrNotFound {
+		t.Fatal("expected key to have expired:", err)
+	}
+}
+
+func TestSetIfNotExists(t *testing.T) {
+	store, err := kvstore.New("memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set("lang", []byte("go"), kvstore.WithIfNotExists()); err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Set("lang", []byte("rust"), kvstore.WithIfNotExists())
+	if err != kvstore.ErrKeyExists {
+		t.Fatal("expected ErrKeyExists, got:", err)
+	}
+}
+
 func TestGetNotFound(t *testing.T) {
 	store, _ := kvstore.New("memory", "")
 
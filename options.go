@@ -0,0 +1,79 @@
+package kvstore
+
+import "time"
+
+// Options carries the resolved options for a Set, Get or Delete call.
+// Not every field is meaningful to every operation; see the With*
+// functions below for which operation each option applies to.
+type Options struct {
+	// TTL expires the key after the given duration. Zero means no
+	// expiration. Set only.
+	TTL time.Duration
+
+	// Consistent requests a strongly consistent read, bypassing any
+	// cache or replica the driver might otherwise use. Get only.
+	Consistent bool
+
+	// IfNotExists makes Set fail with ErrKeyExists if key already
+	// exists. Set only.
+	IfNotExists bool
+
+	// DriverOptions carries driver-specific, non-portable tuning that
+	// doesn't have a dedicated option. Set, Get or Delete.
+	DriverOptions map[string]string
+}
+
+// SetOption configures a Set call.
+type SetOption func(*Options)
+
+// GetOption configures a Get call.
+type GetOption func(*Options)
+
+// DeleteOption configures a Delete call.
+type DeleteOption func(*Options)
+
+// WithTTL makes Set expire key after d.
+//
+// Returns ErrOptionNotSupported if the driver can't honor TTLs.
+func WithTTL(d time.Duration) SetOption {
+	return func(o *Options) { o.TTL = d }
+}
+
+// WithIfNotExists makes Set fail with ErrKeyExists if key already
+// exists, instead of overriding it.
+func WithIfNotExists() SetOption {
+	return func(o *Options) { o.IfNotExists = true }
+}
+
+// WithConsistent makes Get read from the strongly consistent source,
+// mirroring libkv's ReadOptions{Consistent: true}.
+func WithConsistent() GetOption {
+	return func(o *Options) { o.Consistent = true }
+}
+
+// WithDriverOption sets a driver-specific option on a Set call, for
+// tuning behavior that has no portable equivalent across drivers.
+//
+// Returns ErrOptionNotSupported if the driver doesn't recognize key.
+func WithDriverOption(key, value string) SetOption {
+	return func(o *Options) { setDriverOption(o, key, value) }
+}
+
+// WithGetDriverOption sets a driver-specific option on a Get call. See
+// WithDriverOption.
+func WithGetDriverOption(key, value string) GetOption {
+	return func(o *Options) { setDriverOption(o, key, value) }
+}
+
+// WithDeleteDriverOption sets a driver-specific option on a Delete
+// call. See WithDriverOption.
+func WithDeleteDriverOption(key, value string) DeleteOption {
+	return func(o *Options) { setDriverOption(o, key, value) }
+}
+
+func setDriverOption(o *Options, key, value string) {
+	if o.DriverOptions == nil {
+		o.DriverOptions = make(map[string]string)
+	}
+	o.DriverOptions[key] = value
+}
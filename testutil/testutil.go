@@ -5,12 +5,19 @@
 package testutil
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/igorsobreira/kvstore"
 )
 
+// watchTimeout bounds how long TestWatch* tests wait for an event
+// before failing.
+const watchTimeout = 2 * time.Second
+
 const megabyte = 1024 * 1024
 
 // Teardown will be called by TestRequiredAPI after each test is completed.
@@ -28,6 +35,27 @@ var TestFuncs = []TestFunc{
 	TestSetOverride,
 	TestGetNotFound,
 	TestDeleteNotFound,
+	TestBatchSetGet,
+	TestBatchSetDelete,
+	TestIteratorOrder,
+	TestIteratorHalfOpenBounds,
+	TestIteratorEmptyRange,
+	TestIteratorSnapshot,
+	TestBucketIsolation,
+	TestBucketIterator,
+	TestBucketAtomicPut,
+	TestBucketWatch,
+	TestBucketBatch,
+	TestAtomicPutFirstWrite,
+	TestAtomicPutSuccess,
+	TestAtomicPutMismatch,
+	TestAtomicPutContention,
+	TestWatchKey,
+	TestWatchPrefix,
+	TestWatchCancel,
+	TestWatchMultipleSubscribers,
+	TestSetTTL,
+	TestSetIfNotExists,
 }
 
 // TestRequiredAPI will run all Test* functions defined in this package
@@ -156,6 +184,633 @@ func TestDeleteNotFound(t *testing.T, kv *kvstore.KVStore) {
 	}
 }
 
+// TestBatchSetGet tests that a batch of Set operations is visible
+// after Commit
+func TestBatchSetGet(t *testing.T, kv *kvstore.KVStore) {
+
+	b := kv.Batch()
+	b.Set("key1", []byte("value1"))
+	b.Set("key2", []byte("value2"))
+
+	if err := b.Commit(); err != nil {
+		t.Fatal("commit failed:", err)
+	}
+
+	val, err := kv.Get("key1")
+	if err != nil {
+		t.Error("get key1 failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value1")) {
+		t.Errorf("key1 got %#v, want %#v", Truncate(val), Truncate([]byte("value1")))
+	}
+
+	val, err = kv.Get("key2")
+	if err != nil {
+		t.Error("get key2 failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value2")) {
+		t.Errorf("key2 got %#v, want %#v", Truncate(val), Truncate([]byte("value2")))
+	}
+}
+
+// TestBatchSetDelete tests that a Delete op in a batch removes a key
+// set in that same batch
+func TestBatchSetDelete(t *testing.T, kv *kvstore.KVStore) {
+
+	kv.Set("key", []byte("value"))
+
+	b := kv.Batch()
+	b.Set("key", []byte("new-value"))
+	b.Delete("key")
+
+	if err := b.Commit(); err != nil {
+		t.Fatal("commit failed:", err)
+	}
+
+	_, err := kv.Get("key")
+	if err != kvstore.ErrNotFound {
+		t.Error("invalid error after batch delete:", err)
+	}
+}
+
+// TestIteratorOrder tests that Iterator() visits keys in ascending
+// order and returns the expected values
+func TestIteratorOrder(t *testing.T, kv *kvstore.KVStore) {
+
+	kv.Set("b", []byte("2"))
+	kv.Set("a", []byte("1"))
+	kv.Set("c", []byte("3"))
+
+	it, err := kv.Iterator("a", "d")
+	if err != nil {
+		t.Fatal("iterator failed:", err)
+	}
+	defer it.Close()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal("iteration failed:", err)
+	}
+
+	want := []string{"a", "b", "c"}
+	if len(keys) != len(want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("got keys %v, want %v", keys, want)
+			break
+		}
+	}
+}
+
+// TestIteratorHalfOpenBounds tests that Iterator() includes start but
+// excludes end, and that PrefixIterator() only returns keys with the
+// given prefix
+func TestIteratorHalfOpenBounds(t *testing.T, kv *kvstore.KVStore) {
+
+	kv.Set("a", []byte("1"))
+	kv.Set("b", []byte("2"))
+	kv.Set("c", []byte("3"))
+
+	it, err := kv.Iterator("a", "c")
+	if err != nil {
+		t.Fatal("iterator failed:", err)
+	}
+	defer it.Close()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("got %v, want [a b]", keys)
+	}
+
+	kv.Set("prefix:1", []byte("1"))
+	kv.Set("prefix:2", []byte("2"))
+	kv.Set("other", []byte("3"))
+
+	pit, err := kv.PrefixIterator("prefix:")
+	if err != nil {
+		t.Fatal("prefix iterator failed:", err)
+	}
+	defer pit.Close()
+
+	keys = nil
+	for pit.Next() {
+		keys = append(keys, pit.Key())
+	}
+
+	if len(keys) != 2 || keys[0] != "prefix:1" || keys[1] != "prefix:2" {
+		t.Fatalf("got %v, want [prefix:1 prefix:2]", keys)
+	}
+}
+
+// TestIteratorEmptyRange tests that Iterator() returns an iterator
+// that yields nothing when the range contains no keys
+func TestIteratorEmptyRange(t *testing.T, kv *kvstore.KVStore) {
+
+	kv.Set("x", []byte("1"))
+
+	it, err := kv.Iterator("a", "b")
+	if err != nil {
+		t.Fatal("iterator failed:", err)
+	}
+	defer it.Close()
+
+	if it.Next() {
+		t.Error("expected no keys, got:", it.Key())
+	}
+	if err := it.Error(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+// TestIteratorSnapshot tests that keys set or deleted after the
+// iterator was created don't affect its results
+func TestIteratorSnapshot(t *testing.T, kv *kvstore.KVStore) {
+
+	kv.Set("a", []byte("1"))
+	kv.Set("b", []byte("2"))
+
+	it, err := kv.Iterator("a", "z")
+	if err != nil {
+		t.Fatal("iterator failed:", err)
+	}
+	defer it.Close()
+
+	kv.Set("c", []byte("3"))
+	kv.Delete("a")
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("got %v, want [a b]", keys)
+	}
+}
+
+// TestBucketIsolation tests that identical keys in two buckets don't
+// collide and that deleting a key in one bucket doesn't affect the
+// other
+func TestBucketIsolation(t *testing.T, kv *kvstore.KVStore) {
+
+	a := kv.Bucket("a")
+	b := kv.Bucket("b")
+
+	if err := a.Set("key", []byte("a-value")); err != nil {
+		t.Fatal("set in bucket a failed:", err)
+	}
+	if err := b.Set("key", []byte("b-value")); err != nil {
+		t.Fatal("set in bucket b failed:", err)
+	}
+
+	val, err := a.Get("key")
+	if err != nil {
+		t.Fatal("get from bucket a failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("a-value")) {
+		t.Errorf("bucket a got %#v, want %#v", Truncate(val), Truncate([]byte("a-value")))
+	}
+
+	val, err = b.Get("key")
+	if err != nil {
+		t.Fatal("get from bucket b failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("b-value")) {
+		t.Errorf("bucket b got %#v, want %#v", Truncate(val), Truncate([]byte("b-value")))
+	}
+
+	if err := a.Delete("key"); err != nil {
+		t.Fatal("delete from bucket a failed:", err)
+	}
+
+	_, err = a.Get("key")
+	if err != kvstore.ErrNotFound {
+		t.Error("bucket a: invalid error after delete:", err)
+	}
+
+	val, err = b.Get("key")
+	if err != nil {
+		t.Fatal("bucket b affected by bucket a delete:", err)
+	}
+	if !ByteSliceEqual(val, []byte("b-value")) {
+		t.Errorf("bucket b got %#v, want %#v", Truncate(val), Truncate([]byte("b-value")))
+	}
+}
+
+// TestBucketIterator tests that a bucket scoped with KVStore.Bucket()
+// still supports Iterator() when the underlying driver does, only
+// seeing its own keys and with the prefix stripped back off
+func TestBucketIterator(t *testing.T, kv *kvstore.KVStore) {
+
+	if _, err := kv.Iterator("", ""); err == kvstore.ErrNotSupported {
+		t.Skip("driver doesn't support Iterator")
+	}
+
+	bucket := kv.Bucket("ns")
+
+	bucket.Set("a", []byte("1"))
+	bucket.Set("b", []byte("2"))
+	kv.Set("ns-looks-similar-but-isnt", []byte("3"))
+
+	it, err := bucket.Iterator("", "")
+	if err != nil {
+		t.Fatal("bucket iterator failed:", err)
+	}
+	defer it.Close()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal("iteration failed:", err)
+	}
+
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("got %v, want [a b]", keys)
+	}
+}
+
+// TestBucketAtomicPut tests that a bucket scoped with KVStore.Bucket()
+// still supports AtomicPut()/AtomicDelete() when the underlying driver
+// does, and that a previous KVPair fetched from the bucket can be used
+// to CAS within that same bucket
+func TestBucketAtomicPut(t *testing.T, kv *kvstore.KVStore) {
+
+	if _, err := kv.GetPair("key"); err == kvstore.ErrNotSupported {
+		t.Skip("driver doesn't support AtomicPut")
+	}
+
+	bucket := kv.Bucket("ns")
+
+	pair, err := bucket.AtomicPut("key", []byte("value1"), nil)
+	if err != nil {
+		t.Fatal("atomic put failed:", err)
+	}
+	if pair.Key != "key" {
+		t.Errorf("pair key got %#v, want %#v", pair.Key, "key")
+	}
+
+	if _, err := bucket.AtomicPut("key", []byte("value2"), nil); err != kvstore.ErrCASMismatch {
+		t.Error("expected ErrCASMismatch, got:", err)
+	}
+
+	pair, err = bucket.AtomicPut("key", []byte("value2"), pair)
+	if err != nil {
+		t.Fatal("update failed:", err)
+	}
+
+	if err := bucket.AtomicDelete("key", pair); err != nil {
+		t.Fatal("atomic delete failed:", err)
+	}
+
+	if _, err := kv.Get("key"); err != kvstore.ErrNotFound {
+		t.Error("root store should not see bucket's key:", err)
+	}
+}
+
+// TestBucketWatch tests that a bucket scoped with KVStore.Bucket()
+// still supports Watch() when the underlying driver does, only seeing
+// events for its own keys and with the prefix stripped back off
+func TestBucketWatch(t *testing.T, kv *kvstore.KVStore) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bucket := kv.Bucket("ns")
+
+	events, err := bucket.Watch(ctx, "key", kvstore.WithChannelSize(1))
+	if err == kvstore.ErrNotSupported {
+		t.Skip("driver doesn't support Watch")
+	}
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+
+	kv.Set("key", []byte("root, should be ignored"))
+	bucket.Set("key", []byte("value1"))
+
+	ev := recvEvent(t, events)
+	if ev.Type != kvstore.EventPut || ev.Key != "key" || !ByteSliceEqual(ev.Value, []byte("value1")) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+// TestBucketBatch tests that a bucket scoped with KVStore.Bucket()
+// still supports Batch() when the underlying driver supports
+// BatchConn, only affecting keys within that bucket
+func TestBucketBatch(t *testing.T, kv *kvstore.KVStore) {
+
+	bucket := kv.Bucket("ns")
+	kv.Set("key1", []byte("root, should be untouched"))
+
+	b := bucket.Batch()
+	b.Set("key1", []byte("value1"))
+	b.Set("key2", []byte("value2"))
+
+	if err := b.Commit(); err != nil {
+		t.Fatal("commit failed:", err)
+	}
+
+	val, err := bucket.Get("key1")
+	if err != nil {
+		t.Error("get key1 failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value1")) {
+		t.Errorf("key1 got %#v, want %#v", Truncate(val), Truncate([]byte("value1")))
+	}
+
+	val, err = bucket.Get("key2")
+	if err != nil {
+		t.Error("get key2 failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value2")) {
+		t.Errorf("key2 got %#v, want %#v", Truncate(val), Truncate([]byte("value2")))
+	}
+
+	val, err = kv.Get("key1")
+	if err != nil {
+		t.Error("get root key1 failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("root, should be untouched")) {
+		t.Errorf("bucket batch affected root key1: %#v", Truncate(val))
+	}
+}
+
+// TestAtomicPutFirstWrite tests that AtomicPut with previous == nil
+// succeeds when the key doesn't exist yet, and fails with
+// ErrCASMismatch when it already does
+func TestAtomicPutFirstWrite(t *testing.T, kv *kvstore.KVStore) {
+
+	pair, err := kv.AtomicPut("key", []byte("value1"), nil)
+	if err != nil {
+		t.Fatal("first write failed:", err)
+	}
+	if pair.Key != "key" || !ByteSliceEqual(pair.Value, []byte("value1")) {
+		t.Errorf("invalid pair returned: %+v", pair)
+	}
+
+	_, err = kv.AtomicPut("key", []byte("value2"), nil)
+	if err != kvstore.ErrCASMismatch {
+		t.Error("expected ErrCASMismatch, got:", err)
+	}
+}
+
+// TestAtomicPutSuccess tests that AtomicPut succeeds when previous
+// matches the key's current pair
+func TestAtomicPutSuccess(t *testing.T, kv *kvstore.KVStore) {
+
+	pair, err := kv.AtomicPut("key", []byte("value1"), nil)
+	if err != nil {
+		t.Fatal("first write failed:", err)
+	}
+
+	pair, err = kv.AtomicPut("key", []byte("value2"), pair)
+	if err != nil {
+		t.Fatal("update failed:", err)
+	}
+
+	got, err := kv.Get("key")
+	if err != nil {
+		t.Fatal("get failed:", err)
+	}
+	if !ByteSliceEqual(got, []byte("value2")) {
+		t.Errorf("got %#v, want %#v", Truncate(got), Truncate([]byte("value2")))
+	}
+
+	if err := kv.AtomicDelete("key", pair); err != nil {
+		t.Fatal("delete failed:", err)
+	}
+	if _, err := kv.Get("key"); err != kvstore.ErrNotFound {
+		t.Error("invalid error after atomic delete:", err)
+	}
+}
+
+// TestAtomicPutMismatch tests that AtomicPut/AtomicDelete reject a
+// stale previous with ErrCASMismatch
+func TestAtomicPutMismatch(t *testing.T, kv *kvstore.KVStore) {
+
+	stale, err := kv.AtomicPut("key", []byte("value1"), nil)
+	if err != nil {
+		t.Fatal("first write failed:", err)
+	}
+
+	if _, err := kv.AtomicPut("key", []byte("value2"), stale); err != nil {
+		t.Fatal("update failed:", err)
+	}
+
+	if _, err := kv.AtomicPut("key", []byte("value3"), stale); err != kvstore.ErrCASMismatch {
+		t.Error("expected ErrCASMismatch on stale put, got:", err)
+	}
+
+	if err := kv.AtomicDelete("key", stale); err != kvstore.ErrCASMismatch {
+		t.Error("expected ErrCASMismatch on stale delete, got:", err)
+	}
+}
+
+// TestAtomicPutContention tests that under concurrent AtomicPut calls
+// racing on the same previous pair, exactly one wins
+func TestAtomicPutContention(t *testing.T, kv *kvstore.KVStore) {
+
+	pair, err := kv.AtomicPut("key", []byte("initial"), nil)
+	if err != nil {
+		t.Fatal("first write failed:", err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+
+	successes := make([]bool, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			_, err := kv.AtomicPut("key", []byte(fmt.Sprintf("value%d", i)), pair)
+			successes[i] = err == nil
+		}(i)
+	}
+	wg.Wait()
+
+	wins := 0
+	for _, ok := range successes {
+		if ok {
+			wins++
+		}
+	}
+	if wins != 1 {
+		t.Errorf("expected exactly 1 winner, got %d", wins)
+	}
+}
+
+// TestWatchKey tests that Watch() delivers Put and Delete events for
+// the watched key, and ignores changes to other keys
+func TestWatchKey(t *testing.T, kv *kvstore.KVStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := kv.Watch(ctx, "key", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+
+	kv.Set("other", []byte("ignored"))
+	kv.Set("key", []byte("value1"))
+
+	ev := recvEvent(t, events)
+	if ev.Type != kvstore.EventPut || ev.Key != "key" || !ByteSliceEqual(ev.Value, []byte("value1")) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	kv.Delete("key")
+
+	ev = recvEvent(t, events)
+	if ev.Type != kvstore.EventDelete || ev.Key != "key" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+// TestWatchPrefix tests that WatchPrefix() delivers events for keys
+// under prefix and ignores keys outside it
+func TestWatchPrefix(t *testing.T, kv *kvstore.KVStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := kv.WatchPrefix(ctx, "prefix:", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+
+	kv.Set("other", []byte("ignored"))
+	kv.Set("prefix:a", []byte("value1"))
+
+	ev := recvEvent(t, events)
+	if ev.Type != kvstore.EventPut || ev.Key != "prefix:a" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+// TestWatchCancel tests that the event channel is closed once ctx is
+// cancelled
+func TestWatchCancel(t *testing.T, kv *kvstore.KVStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := kv.Watch(ctx, "key", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to be closed, got an event")
+		}
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for channel to close")
+	}
+}
+
+// TestWatchMultipleSubscribers tests that multiple concurrent
+// subscribers all see the same events
+func TestWatchMultipleSubscribers(t *testing.T, kv *kvstore.KVStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events1, err := kv.Watch(ctx, "key", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+	events2, err := kv.Watch(ctx, "key", kvstore.WithChannelSize(1))
+	if err != nil {
+		t.Fatal("watch failed:", err)
+	}
+
+	kv.Set("key", []byte("value1"))
+
+	ev1 := recvEvent(t, events1)
+	ev2 := recvEvent(t, events2)
+
+	if ev1.Key != "key" || ev2.Key != "key" {
+		t.Errorf("unexpected events: %+v, %+v", ev1, ev2)
+	}
+}
+
+func recvEvent(t *testing.T, events <-chan kvstore.Event) kvstore.Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for event")
+		return kvstore.Event{}
+	}
+}
+
+// TestSetTTL tests that a key set with WithTTL expires and returns
+// ErrNotFound after the duration elapses. Skips if the driver
+// doesn't support TTL.
+func TestSetTTL(t *testing.T, kv *kvstore.KVStore) {
+
+	err := kv.Set("key", []byte("value"), kvstore.WithTTL(50*time.Millisecond))
+	if err == kvstore.ErrOptionNotSupported {
+		t.Skip("driver doesn't support WithTTL")
+	}
+	if err != nil {
+		t.Fatal("set failed:", err)
+	}
+
+	val, err := kv.Get("key")
+	if err != nil {
+		t.Fatal("get before expiry failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value")) {
+		t.Errorf("got %#v, want %#v", Truncate(val), Truncate([]byte("value")))
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := kv.Get("key"); err != kvstore.ErrNotFound {
+		t.Error("expected key to have expired:", err)
+	}
+}
+
+// TestSetIfNotExists tests that WithIfNotExists rejects overriding an
+// existing key with ErrKeyExists. Skips if the driver doesn't support
+// it.
+func TestSetIfNotExists(t *testing.T, kv *kvstore.KVStore) {
+
+	err := kv.Set("key", []byte("value1"), kvstore.WithIfNotExists())
+	if err == kvstore.ErrOptionNotSupported {
+		t.Skip("driver doesn't support WithIfNotExists")
+	}
+	if err != nil {
+		t.Fatal("first set failed:", err)
+	}
+
+	err = kv.Set("key", []byte("value2"), kvstore.WithIfNotExists())
+	if err != kvstore.ErrKeyExists {
+		t.Error("expected ErrKeyExists, got:", err)
+	}
+
+	val, err := kv.Get("key")
+	if err != nil {
+		t.Fatal("get failed:", err)
+	}
+	if !ByteSliceEqual(val, []byte("value1")) {
+		t.Errorf("got %#v, want %#v (should not have been overridden)", Truncate(val), Truncate([]byte("value1")))
+	}
+}
+
 // ByteSliceEqual compares two []byte and return true if they
 // have the same content (in the same order)
 func ByteSliceEqual(a, b []byte) bool {
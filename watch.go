@@ -0,0 +1,36 @@
+package kvstore
+
+import "context"
+
+// Watch returns a channel of Events reporting Put/Delete changes to
+// key. The channel is closed when ctx is done.
+//
+// Returns ErrNotSupported if the driver doesn't implement WatcherConn.
+func (s *KVStore) Watch(ctx context.Context, key string, opts ...WatchOption) (<-chan Event, error) {
+	wc, ok := s.conn.(WatcherConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return wc.Watch(ctx, key, resolveWatchOptions(opts))
+}
+
+// WatchPrefix returns a channel of Events reporting Put/Delete changes
+// to any key starting with prefix. The channel is closed when ctx is
+// done.
+//
+// Returns ErrNotSupported if the driver doesn't implement WatcherConn.
+func (s *KVStore) WatchPrefix(ctx context.Context, prefix string, opts ...WatchOption) (<-chan Event, error) {
+	wc, ok := s.conn.(WatcherConn)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return wc.WatchPrefix(ctx, prefix, resolveWatchOptions(opts))
+}
+
+func resolveWatchOptions(opts []WatchOption) WatchOptions {
+	var o WatchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}